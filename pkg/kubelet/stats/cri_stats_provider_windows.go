@@ -21,16 +21,29 @@ package stats
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Microsoft/hcsshim"
+	"github.com/Microsoft/hcsshim/hcn"
+	"github.com/Microsoft/hcsshim/osversion"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"k8s.io/kubernetes/pkg/features"
 )
 
+// hcsV2Build is the first Windows build (Windows Server, version 1809) that supports the HCS v2
+// API surface (hcn / HostComputeSystem) used to query HNS v2 endpoints. Nodes running older
+// builds fall back to the legacy HNS v1 API.
+const hcsV2Build = 17763
+
 type hcsShimInterface interface {
 	GetContainers(q hcsshim.ComputeSystemQuery) ([]hcsshim.ContainerProperties, error)
 	GetHNSEndpointByID(endpointID string) (*hcsshim.HNSEndpoint, error)
@@ -51,6 +64,109 @@ func (s windowshim) OpenContainer(id string) (hcsshim.Container, error) {
 	return hcsshim.OpenContainer(id)
 }
 
+// hostProcessContainerType is the HCS v2 compute-system type used for HostProcess containers.
+// They run directly against the host compute system rather than a utility VM or silo, so a
+// v1-style query for "Container" type compute systems alone does not return them.
+const hostProcessContainerType = "HostProcess"
+
+// windowshimV2 is the HCS v2 backed implementation of hcsShimInterface. Opening a container by ID
+// is schema-agnostic, so OpenContainer is reused unchanged from v1. GetContainers and
+// GetHNSEndpointByID are overridden: the former also queries for HostProcess containers, which do
+// not appear in a v1-style container list, and the latter resolves endpoints via hcn so that HNS
+// v2 (HostComputeEndpoint) networks, which HostProcess containers rely on, resolve correctly.
+type windowshimV2 struct {
+	windowshim
+}
+
+func (s windowshimV2) GetContainers(q hcsshim.ComputeSystemQuery) ([]hcsshim.ContainerProperties, error) {
+	containers, err := s.windowshim.GetContainers(q)
+	if err != nil {
+		return nil, err
+	}
+
+	hostProcessQuery := q
+	hostProcessQuery.Types = append(append([]string{}, q.Types...), hostProcessContainerType)
+	hostProcessContainers, err := s.windowshim.GetContainers(hostProcessQuery)
+	if err != nil {
+		klog.V(4).InfoS("Failed to query HostProcess containers, continuing with the containers already found", "err", err)
+		return containers, nil
+	}
+
+	return mergeContainersByID(containers, hostProcessContainers), nil
+}
+
+// mergeContainersByID appends the entries of additional to base that aren't already present in
+// base, de-duplicating by container ID. It's split out of GetContainers so the HostProcess merge
+// behavior can be unit tested without a real HCS v1 connection backing windowshim.
+func mergeContainersByID(base, additional []hcsshim.ContainerProperties) []hcsshim.ContainerProperties {
+	seen := sets.NewString()
+	for _, c := range base {
+		seen.Insert(c.ID)
+	}
+	for _, c := range additional {
+		if seen.Has(c.ID) {
+			continue
+		}
+		base = append(base, c)
+		seen.Insert(c.ID)
+	}
+	return base
+}
+
+func (s windowshimV2) GetHNSEndpointByID(endpointID string) (*hcsshim.HNSEndpoint, error) {
+	endpoint, err := hcn.GetEndpointByID(endpointID)
+	if err != nil {
+		return nil, err
+	}
+	return hcnEndpointToHNSEndpoint(endpoint)
+}
+
+// hcnEndpointToHNSEndpoint translates a HCS v2 hcn.HostComputeEndpoint into the legacy
+// hcsshim.HNSEndpoint shape so that callers written against the v1 types keep working regardless
+// of which HCS schema backs the node. HCS v2 has no SharedContainers list on the endpoint itself
+// (unlike the v1 HNSEndpoint) — containers are associated with an endpoint through the HCS
+// namespace instead — so SharedContainers is reconstructed from the endpoint's namespace.
+func hcnEndpointToHNSEndpoint(endpoint *hcn.HostComputeEndpoint) (*hcsshim.HNSEndpoint, error) {
+	sharedContainers, err := sharedContainersForNamespace(endpoint.HostComputeNamespace)
+	if err != nil {
+		return nil, err
+	}
+	return &hcsshim.HNSEndpoint{
+		Id:               endpoint.Id,
+		Name:             endpoint.Name,
+		SharedContainers: sharedContainers,
+	}, nil
+}
+
+// sharedContainersForNamespace returns the IDs of every container attached to the given HCS v2
+// namespace, mirroring the v1 HNSEndpoint.SharedContainers list that callers already key their
+// pod-sandbox correlation on (see listPodSandboxNetworkStats).
+func sharedContainersForNamespace(namespaceID string) ([]string, error) {
+	if namespaceID == "" {
+		return nil, nil
+	}
+
+	namespace, err := hcn.GetNamespaceByID(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	containerIDs := make([]string, 0, len(namespace.Resources))
+	for _, resource := range namespace.Resources {
+		if resource.Type == hcn.ResourceTypeContainer {
+			containerIDs = append(containerIDs, resource.Data)
+		}
+	}
+	return containerIDs, nil
+}
+
+// usingHcsV2 reports whether the node's Windows build supports the HCS v2 API. HostProcess
+// containers only ever attach via HNS v2 endpoints, so nodes on older builds simply won't see
+// their network stats through either backend.
+func usingHcsV2() bool {
+	return osversion.Build() >= hcsV2Build
+}
+
 // listContainerNetworkStats returns the network stats of all the running containers.
 func (p *criStatsProvider) listContainerNetworkStats() (map[string]*statsapi.NetworkStats, error) {
 	shim := newHcsShim(p)
@@ -76,14 +192,221 @@ func (p *criStatsProvider) listContainerNetworkStats() (map[string]*statsapi.Net
 	return stats, nil
 }
 
+// containerStats holds the CPU and memory stats collected for a single container via hcsshim,
+// mirroring the subset of statsapi.ContainerStats that Windows can populate without cAdvisor.
+// hcsshim.StorageStats has no current-filesystem-usage field — WriteSizeBytes is a cumulative
+// write-throughput counter, not space consumed — so filesystem stats are deliberately not
+// populated here rather than mislabeling that counter as disk usage.
+type containerStats struct {
+	CPU    *statsapi.CPUStats
+	Memory *statsapi.MemoryStats
+}
+
+// listContainerStats returns the CPU and memory stats of all the running containers.
+func (p *criStatsProvider) listContainerStats() (map[string]*containerStats, error) {
+	shim := newHcsShim(p)
+	containers, err := shim.GetContainers(hcsshim.ComputeSystemQuery{
+		Types: []string{"Container"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]*containerStats)
+	for _, c := range containers {
+		cstats, err := fetchContainerStats(shim, c)
+		if err != nil {
+			klog.V(4).InfoS("Failed to fetch statistics for container, continue to get stats for other containers", "containerID", c.ID, "err", err)
+			continue
+		}
+
+		stats[c.ID] = &containerStats{
+			CPU:    hcsStatsToCPUStats(cstats.Timestamp, cstats.Processor),
+			Memory: hcsStatsToMemoryStats(cstats.Timestamp, cstats.Memory),
+		}
+	}
+
+	return stats, nil
+}
+
+// hcsStatsToCPUStats converts hcsshim.Statistics.Processor to statsapi.CPUStats.
+func hcsStatsToCPUStats(timestamp time.Time, processor hcsshim.ProcessorStats) *statsapi.CPUStats {
+	usageCoreNanoSeconds := processor.TotalRuntime100ns * 100
+	return &statsapi.CPUStats{
+		Time:                 metav1.NewTime(timestamp),
+		UsageCoreNanoSeconds: &usageCoreNanoSeconds,
+	}
+}
+
+// hcsStatsToMemoryStats converts hcsshim.Statistics.Memory to statsapi.MemoryStats.
+func hcsStatsToMemoryStats(timestamp time.Time, memory hcsshim.MemoryStats) *statsapi.MemoryStats {
+	workingSetBytes := memory.UsagePrivateWorkingSetBytes
+	usageBytes := memory.UsageCommitBytes
+	return &statsapi.MemoryStats{
+		Time:            metav1.NewTime(timestamp),
+		UsageBytes:      &usageBytes,
+		WorkingSetBytes: &workingSetBytes,
+	}
+}
+
+// listPodSandboxNetworkStats returns the network stats of the given pod sandboxes, grouped by
+// HNS namespace so that containers sharing a pod's network namespace (and therefore its HNS
+// endpoints) contribute to a single, de-duplicated NetworkStats entry per pod sandbox instead of
+// one entry per container. This avoids double-counting endpoint traffic for multi-container pods.
+func (p *criStatsProvider) listPodSandboxNetworkStats(podSandboxIDs []string) (map[string]*statsapi.NetworkStats, error) {
+	shim := newHcsShim(p)
+	containers, err := shim.GetContainers(hcsshim.ComputeSystemQuery{
+		Types: []string{"Container"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch each sandbox container's stats once and reuse them below for both endpoint
+	// discovery and network stats, instead of issuing a second HCS RPC for the same container.
+	wantedSandboxes := sets.NewString(podSandboxIDs...)
+	sandboxStats := make(map[string]hcsshim.Statistics)
+	for _, c := range containers {
+		if !wantedSandboxes.Has(c.ID) {
+			continue
+		}
+		cstats, err := fetchContainerStats(shim, c)
+		if err != nil {
+			klog.V(4).InfoS("Failed to fetch statistics for pod sandbox, continue to get stats for other sandboxes", "podSandboxID", c.ID, "err", err)
+			continue
+		}
+		sandboxStats[c.ID] = cstats
+	}
+
+	// Map every container ID that shares an HNS endpoint with a pod sandbox back to that
+	// sandbox's ID, using HNSEndpoint.SharedContainers as the namespace correlation source.
+	containerToSandbox := make(map[string]string)
+	for sandboxID, cstats := range sandboxStats {
+		containerToSandbox[sandboxID] = sandboxID
+		for _, n := range cstats.Network {
+			endpoint, err := getHNSEndpointCache().get(shim, n.EndpointId)
+			if err != nil {
+				klog.V(4).InfoS("Failed to get HNS endpoint, continue to correlate other endpoints", "endpointID", n.EndpointId, "err", err)
+				continue
+			}
+			for _, sharedID := range endpoint.SharedContainers {
+				containerToSandbox[sharedID] = sandboxID
+			}
+		}
+	}
+
+	stats := make(map[string]*statsapi.NetworkStats)
+	endpointIDsBySandbox := make(map[string]map[string]string)
+	for _, c := range containers {
+		sandboxID, ok := containerToSandbox[c.ID]
+		if !ok {
+			continue
+		}
+
+		cstats, ok := sandboxStats[c.ID]
+		if !ok {
+			var err error
+			cstats, err = fetchContainerStats(shim, c)
+			if err != nil {
+				klog.V(4).InfoS("Failed to fetch statistics for container, continue to get stats for other containers", "containerID", c.ID, "err", err)
+				continue
+			}
+		}
+		if len(cstats.Network) == 0 {
+			continue
+		}
+
+		if existing, found := stats[sandboxID]; found {
+			mergeInterfaceStats(existing, shim, cstats.Network)
+		} else {
+			stats[sandboxID] = hcsStatsToNetworkStats(shim, cstats.Timestamp, cstats.Network)
+		}
+
+		for name, id := range interfaceEndpointIDs(shim, cstats.Network) {
+			if endpointIDsBySandbox[sandboxID] == nil {
+				endpointIDsBySandbox[sandboxID] = make(map[string]string)
+			}
+			endpointIDsBySandbox[sandboxID][name] = id
+		}
+	}
+
+	p.recordPodNetworkStatsMetrics(podSandboxIDs, stats, endpointIDsBySandbox)
+
+	return stats, nil
+}
+
+// recordPodNetworkStatsMetrics exports each pod sandbox's network stats as Prometheus metrics and
+// prunes series for sandboxes that were requested previously but no longer exist.
+func (p *criStatsProvider) recordPodNetworkStatsMetrics(podSandboxIDs []string, stats map[string]*statsapi.NetworkStats, endpointIDsBySandbox map[string]map[string]string) {
+	if !utilfeature.DefaultFeatureGate.Enabled(features.WindowsNetworkStatsPrometheus) {
+		return
+	}
+
+	for sandboxID, netStats := range stats {
+		status, err := p.runtimeService.PodSandboxStatus(sandboxID)
+		if err != nil || status.GetMetadata() == nil {
+			klog.V(4).InfoS("Failed to get pod sandbox status, skipping network stats metrics for this pod", "podSandboxID", sandboxID, "err", err)
+			continue
+		}
+
+		windowsPodNetworkStats.update(sandboxID, status.GetMetadata().GetName(), status.GetMetadata().GetNamespace(), netStats, endpointIDsBySandbox[sandboxID])
+	}
+	windowsPodNetworkStats.prune(podSandboxIDs)
+}
+
+// windowsListPodStats is the entry point the platform-agnostic summary-building code in
+// cri_stats_provider.go calls on Windows nodes to get pod-scoped network stats together with each
+// container's CPU and memory stats, in place of the per-container network stats
+// listContainerNetworkStats and cAdvisor-backed CPU/memory stats used on other platforms.
+func (p *criStatsProvider) windowsListPodStats(podSandboxIDs []string) (map[string]*containerStats, map[string]*statsapi.NetworkStats, error) {
+	cStats, err := p.listContainerStats()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	netStats, err := p.listPodSandboxNetworkStats(podSandboxIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cStats, netStats, nil
+}
+
+// mergeInterfaceStats merges the interfaces produced from hcsStats into an already-populated
+// NetworkStats, de-duplicating by adapter name so that endpoints shared between containers of the
+// same pod are only counted once.
+func mergeInterfaceStats(dest *statsapi.NetworkStats, hcsshimInterface hcsShimInterface, hcsStats []hcsshim.NetworkStats) {
+	adapters := sets.NewString()
+	for _, iStat := range dest.Interfaces {
+		adapters.Insert(iStat.Name)
+	}
+
+	for _, stat := range hcsStats {
+		iStat, err := hcsStatsToInterfaceStats(hcsshimInterface, stat)
+		if err != nil {
+			klog.InfoS("Failed to get HNS endpoint, continue to get stats for other endpoints", "endpointID", stat.EndpointId, "err", err)
+			continue
+		}
+		if adapters.Has(iStat.Name) {
+			continue
+		}
+		dest.Interfaces = append(dest.Interfaces, *iStat)
+		adapters.Insert(iStat.Name)
+	}
+
+	if len(dest.Interfaces) > 0 {
+		dest.InterfaceStats = dest.Interfaces[0]
+	}
+}
+
 func newHcsShim(p *criStatsProvider) hcsShimInterface {
-	var shim hcsShimInterface
-	if p.hcsshimInterface == nil {
-		shim = windowshim{}
-	} else {
-		shim = p.hcsshimInterface.(hcsShimInterface)
+	if p.hcsshimInterface != nil {
+		return p.hcsshimInterface.(hcsShimInterface)
 	}
-	return shim
+	if usingHcsV2() {
+		return windowshimV2{}
+	}
+	return windowshim{}
 }
 
 func fetchContainerStats(hcsshimInterface hcsShimInterface, c hcsshim.ContainerProperties) (stats hcsshim.Statistics, err error) {
@@ -131,7 +454,8 @@ func hcsStatsToNetworkStats(hcsshimInterface hcsShimInterface, timestamp time.Ti
 		adapters.Insert(iStat.Name)
 	}
 
-	// TODO(feiskyer): add support of multiple interfaces for getting default interface.
+	// InterfaceStats holds the default interface; callers that need every interface should use
+	// Interfaces instead, which contains all endpoints attached to the container's namespace.
 	if len(result.Interfaces) > 0 {
 		result.InterfaceStats = result.Interfaces[0]
 	}
@@ -141,14 +465,232 @@ func hcsStatsToNetworkStats(hcsshimInterface hcsShimInterface, timestamp time.Ti
 
 // hcsStatsToInterfaceStats converts hcsshim.NetworkStats to statsapi.InterfaceStats.
 func hcsStatsToInterfaceStats(hcsshimInterface hcsShimInterface, stat hcsshim.NetworkStats) (*statsapi.InterfaceStats, error) {
-	endpoint, err := hcsshimInterface.GetHNSEndpointByID(stat.EndpointId)
+	endpoint, err := getHNSEndpointCache().get(hcsshimInterface, stat.EndpointId)
 	if err != nil {
 		return nil, err
 	}
 
+	// statsapi.InterfaceStats has no dropped-packet counters, and hcsshim.NetworkStats has no
+	// read/write error counters of its own, so dropped packets are reported as errors here.
 	return &statsapi.InterfaceStats{
-		Name:    endpoint.Name,
-		RxBytes: &stat.BytesReceived,
-		TxBytes: &stat.BytesSent,
+		Name:     endpoint.Name,
+		RxBytes:  &stat.BytesReceived,
+		TxBytes:  &stat.BytesSent,
+		RxErrors: &stat.DroppedPacketsIncoming,
+		TxErrors: &stat.DroppedPacketsOutgoing,
 	}, nil
 }
+
+// interfaceEndpointIDs builds the interface-name-to-endpoint-ID map that recordPodNetworkStatsMetrics
+// needs, reusing the same cached lookups hcsStatsToInterfaceStats already performed.
+func interfaceEndpointIDs(hcsshimInterface hcsShimInterface, hcsStats []hcsshim.NetworkStats) map[string]string {
+	endpointIDs := make(map[string]string, len(hcsStats))
+	for _, stat := range hcsStats {
+		endpoint, err := getHNSEndpointCache().get(hcsshimInterface, stat.EndpointId)
+		if err != nil {
+			continue
+		}
+		endpointIDs[endpoint.Name] = stat.EndpointId
+	}
+	return endpointIDs
+}
+
+var podNetworkStatsLabels = []string{"pod", "namespace", "interface", "endpoint_id"}
+
+var (
+	windowsPodNetworkRxBytesDesc = metrics.NewDesc("kubelet_windows_pod_network_receive_bytes_total",
+		"Cumulative bytes received by a pod's network interface, as reported by HNS.", podNetworkStatsLabels, nil, metrics.ALPHA, "")
+	windowsPodNetworkTxBytesDesc = metrics.NewDesc("kubelet_windows_pod_network_transmit_bytes_total",
+		"Cumulative bytes transmitted by a pod's network interface, as reported by HNS.", podNetworkStatsLabels, nil, metrics.ALPHA, "")
+	windowsPodNetworkRxErrorsDesc = metrics.NewDesc("kubelet_windows_pod_network_receive_errors_total",
+		"Cumulative receive errors on a pod's network interface, taken from the incoming packets HNS reports as dropped.", podNetworkStatsLabels, nil, metrics.ALPHA, "")
+	windowsPodNetworkTxErrorsDesc = metrics.NewDesc("kubelet_windows_pod_network_transmit_errors_total",
+		"Cumulative transmit errors on a pod's network interface, taken from the outgoing packets HNS reports as dropped.", podNetworkStatsLabels, nil, metrics.ALPHA, "")
+)
+
+// windowsPodNetworkStatsSample is the latest observed value for one (pod, namespace, interface,
+// endpoint) series.
+type windowsPodNetworkStatsSample struct {
+	labels                               []string
+	rxBytes, txBytes, rxErrors, txErrors float64
+}
+
+// windowsPodNetworkStatsCollector is a metrics.BaseStableCollector that reports the current,
+// already-cumulative HNS counters directly rather than through WithLabelValues().Add(), and drops
+// a pod sandbox's series once it stops appearing in a scrape so removed pods don't leak labels.
+type windowsPodNetworkStatsCollector struct {
+	metrics.BaseStableCollector
+
+	mu      sync.Mutex
+	samples map[string]map[string]windowsPodNetworkStatsSample // keyed by pod sandbox ID, then interface name
+}
+
+var windowsPodNetworkStats = &windowsPodNetworkStatsCollector{samples: make(map[string]map[string]windowsPodNetworkStatsSample)}
+
+func init() {
+	legacyregistry.CustomMustRegister(windowsPodNetworkStats)
+}
+
+// update records the latest sample for every interface of a pod sandbox. HNS already reports
+// these values as running totals, so each sample is stored and later reported as-is rather than
+// accumulated, which would double-count. endpointIDs maps interface name to the HNS endpoint
+// backing it, since a pod's NetworkStats can be merged from multiple containers attached to
+// different endpoints (see mergeInterfaceStats).
+func (c *windowsPodNetworkStatsCollector) update(podSandboxID, pod, namespace string, stats *statsapi.NetworkStats, endpointIDs map[string]string) {
+	if len(stats.Interfaces) == 0 {
+		return
+	}
+
+	sandboxSamples := make(map[string]windowsPodNetworkStatsSample, len(stats.Interfaces))
+	for _, iStat := range stats.Interfaces {
+		sample := windowsPodNetworkStatsSample{labels: []string{pod, namespace, iStat.Name, endpointIDs[iStat.Name]}}
+		if iStat.RxBytes != nil {
+			sample.rxBytes = float64(*iStat.RxBytes)
+		}
+		if iStat.TxBytes != nil {
+			sample.txBytes = float64(*iStat.TxBytes)
+		}
+		if iStat.RxErrors != nil {
+			sample.rxErrors = float64(*iStat.RxErrors)
+		}
+		if iStat.TxErrors != nil {
+			sample.txErrors = float64(*iStat.TxErrors)
+		}
+		sandboxSamples[iStat.Name] = sample
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples[podSandboxID] = sandboxSamples
+}
+
+// prune drops samples for pod sandboxes that are no longer present, so deleted pods don't leave
+// stale label combinations registered forever.
+func (c *windowsPodNetworkStatsCollector) prune(livePodSandboxIDs []string) {
+	live := sets.NewString(livePodSandboxIDs...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id := range c.samples {
+		if !live.Has(id) {
+			delete(c.samples, id)
+		}
+	}
+}
+
+func (c *windowsPodNetworkStatsCollector) DescribeWithStability(ch chan<- *metrics.Desc) {
+	ch <- windowsPodNetworkRxBytesDesc
+	ch <- windowsPodNetworkTxBytesDesc
+	ch <- windowsPodNetworkRxErrorsDesc
+	ch <- windowsPodNetworkTxErrorsDesc
+}
+
+func (c *windowsPodNetworkStatsCollector) CollectWithStability(ch chan<- metrics.Metric) {
+	c.mu.Lock()
+	samples := make([]windowsPodNetworkStatsSample, 0, len(c.samples))
+	for _, sandboxSamples := range c.samples {
+		for _, sample := range sandboxSamples {
+			samples = append(samples, sample)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, s := range samples {
+		ch <- metrics.NewLazyConstMetric(windowsPodNetworkRxBytesDesc, metrics.CounterValue, s.rxBytes, s.labels...)
+		ch <- metrics.NewLazyConstMetric(windowsPodNetworkTxBytesDesc, metrics.CounterValue, s.txBytes, s.labels...)
+		ch <- metrics.NewLazyConstMetric(windowsPodNetworkRxErrorsDesc, metrics.CounterValue, s.rxErrors, s.labels...)
+		ch <- metrics.NewLazyConstMetric(windowsPodNetworkTxErrorsDesc, metrics.CounterValue, s.txErrors, s.labels...)
+	}
+}
+
+// hnsEndpointCacheTTL bounds how long a resolved HNS endpoint is reused before it is looked up
+// again. GetHNSEndpointByID is an RPC into the HNS service, and without caching it is issued once
+// per endpoint on every summary scrape, which dominates CPU on nodes with many pods. Operators can
+// tune this via SetHNSEndpointCacheTTL before the cache is first used.
+var hnsEndpointCacheTTL = 30 * time.Second
+
+// SetHNSEndpointCacheTTL overrides the TTL used to cache resolved HNS endpoints. It must be called
+// before the cache is first used (e.g. while processing kubelet flags/config at startup); once the
+// cache has been initialized with the default, later calls have no effect.
+func SetHNSEndpointCacheTTL(ttl time.Duration) {
+	hnsEndpointCacheTTL = ttl
+}
+
+var (
+	hnsEndpointCacheOnce sync.Once
+	hnsEndpointCacheInst *hnsEndpointResolver
+)
+
+// getHNSEndpointCache lazily constructs the process-wide HNS endpoint cache using whatever TTL is
+// in effect at the time of first use.
+func getHNSEndpointCache() *hnsEndpointResolver {
+	hnsEndpointCacheOnce.Do(func() {
+		hnsEndpointCacheInst = newHNSEndpointResolver(hnsEndpointCacheTTL)
+	})
+	return hnsEndpointCacheInst
+}
+
+var (
+	hnsEndpointCacheHitsTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Subsystem:      "kubelet",
+		Name:           "hns_endpoint_cache_hits_total",
+		Help:           "Cumulative number of HNS endpoint lookups for Windows network stats served from cache.",
+		StabilityLevel: metrics.ALPHA,
+	})
+	hnsEndpointCacheMissesTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Subsystem:      "kubelet",
+		Name:           "hns_endpoint_cache_misses_total",
+		Help:           "Cumulative number of HNS endpoint lookups for Windows network stats that required an RPC to HNS.",
+		StabilityLevel: metrics.ALPHA,
+	})
+)
+
+func init() {
+	legacyregistry.MustRegister(hnsEndpointCacheHitsTotal)
+	legacyregistry.MustRegister(hnsEndpointCacheMissesTotal)
+}
+
+// hnsEndpointCacheEntry is a single cached endpoint resolution, along with the adapter name used
+// for de-duplicating interfaces so callers don't need a second RPC just to dedup.
+type hnsEndpointCacheEntry struct {
+	endpoint *hcsshim.HNSEndpoint
+	expires  time.Time
+}
+
+// hnsEndpointResolver is a TTL-bounded, concurrency-safe cache in front of
+// hcsShimInterface.GetHNSEndpointByID. There is currently no HNS notification channel plumbed
+// into the kubelet, so entries are invalidated purely on TTL expiry.
+type hnsEndpointResolver struct {
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]hnsEndpointCacheEntry
+}
+
+func newHNSEndpointResolver(ttl time.Duration) *hnsEndpointResolver {
+	return &hnsEndpointResolver{
+		ttl:     ttl,
+		entries: make(map[string]hnsEndpointCacheEntry),
+	}
+}
+
+func (r *hnsEndpointResolver) get(hcsshimInterface hcsShimInterface, endpointID string) (*hcsshim.HNSEndpoint, error) {
+	r.mu.RLock()
+	entry, ok := r.entries[endpointID]
+	r.mu.RUnlock()
+	if ok && time.Now().Before(entry.expires) {
+		hnsEndpointCacheHitsTotal.Inc()
+		return entry.endpoint, nil
+	}
+
+	hnsEndpointCacheMissesTotal.Inc()
+	endpoint, err := hcsshimInterface.GetHNSEndpointByID(endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.entries[endpointID] = hnsEndpointCacheEntry{endpoint: endpoint, expires: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	return endpoint, nil
+}
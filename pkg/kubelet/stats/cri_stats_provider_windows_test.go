@@ -0,0 +1,213 @@
+//go:build windows
+// +build windows
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Microsoft/hcsshim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// fakeHcsShim is a hcsShimInterface backed by canned responses, so listContainerStats and the
+// network stats paths can be exercised without a real HCS connection.
+type fakeHcsShim struct {
+	containers    []hcsshim.ContainerProperties
+	containersErr error
+
+	statsByContainer map[string]hcsshim.Statistics
+	openErr          map[string]error
+
+	endpoints map[string]*hcsshim.HNSEndpoint
+}
+
+func (f *fakeHcsShim) GetContainers(q hcsshim.ComputeSystemQuery) ([]hcsshim.ContainerProperties, error) {
+	return f.containers, f.containersErr
+}
+
+func (f *fakeHcsShim) GetHNSEndpointByID(endpointID string) (*hcsshim.HNSEndpoint, error) {
+	endpoint, ok := f.endpoints[endpointID]
+	if !ok {
+		return nil, fmt.Errorf("no such endpoint: %s", endpointID)
+	}
+	return endpoint, nil
+}
+
+func (f *fakeHcsShim) OpenContainer(id string) (hcsshim.Container, error) {
+	if err, ok := f.openErr[id]; ok {
+		return nil, err
+	}
+	return &fakeContainer{stats: f.statsByContainer[id]}, nil
+}
+
+// fakeContainer implements hcsshim.Container by embedding the nil interface and overriding only
+// the methods listContainerStats exercises; any other method call would panic, which is fine
+// since these tests never reach them.
+type fakeContainer struct {
+	hcsshim.Container
+	stats hcsshim.Statistics
+}
+
+func (f *fakeContainer) Statistics() (hcsshim.Statistics, error) {
+	return f.stats, nil
+}
+
+func (f *fakeContainer) Close() error {
+	return nil
+}
+
+func TestHcsStatsToCPUStats(t *testing.T) {
+	now := time.Now()
+	cpu := hcsStatsToCPUStats(now, hcsshim.ProcessorStats{TotalRuntime100ns: 12345})
+
+	require.NotNil(t, cpu.UsageCoreNanoSeconds)
+	assert.Equal(t, uint64(1234500), *cpu.UsageCoreNanoSeconds)
+	assert.True(t, cpu.Time.Time.Equal(now))
+}
+
+func TestHcsStatsToMemoryStats(t *testing.T) {
+	now := time.Now()
+	mem := hcsStatsToMemoryStats(now, hcsshim.MemoryStats{
+		UsagePrivateWorkingSetBytes: 1024,
+		UsageCommitBytes:            2048,
+	})
+
+	require.NotNil(t, mem.WorkingSetBytes)
+	require.NotNil(t, mem.UsageBytes)
+	assert.Equal(t, uint64(1024), *mem.WorkingSetBytes)
+	assert.Equal(t, uint64(2048), *mem.UsageBytes)
+}
+
+func TestListContainerStats(t *testing.T) {
+	now := time.Now()
+	shim := &fakeHcsShim{
+		containers: []hcsshim.ContainerProperties{{ID: "c1"}, {ID: "c2"}},
+		statsByContainer: map[string]hcsshim.Statistics{
+			"c1": {
+				Timestamp: now,
+				Processor: hcsshim.ProcessorStats{TotalRuntime100ns: 100},
+				Memory:    hcsshim.MemoryStats{UsagePrivateWorkingSetBytes: 10, UsageCommitBytes: 20},
+			},
+		},
+		openErr: map[string]error{
+			"c2": fmt.Errorf("container not found"),
+		},
+	}
+
+	p := &criStatsProvider{hcsshimInterface: shim}
+	stats, err := p.listContainerStats()
+	require.NoError(t, err)
+
+	// c2 failed to open and should be skipped rather than failing the whole call.
+	require.Len(t, stats, 1)
+	require.Contains(t, stats, "c1")
+
+	c1 := stats["c1"]
+	require.NotNil(t, c1.CPU.UsageCoreNanoSeconds)
+	assert.Equal(t, uint64(10000), *c1.CPU.UsageCoreNanoSeconds)
+	require.NotNil(t, c1.Memory.WorkingSetBytes)
+	assert.Equal(t, uint64(10), *c1.Memory.WorkingSetBytes)
+}
+
+func TestListPodSandboxNetworkStats(t *testing.T) {
+	now := time.Now()
+	shim := &fakeHcsShim{
+		containers: []hcsshim.ContainerProperties{{ID: "sandbox1"}, {ID: "app1"}},
+		statsByContainer: map[string]hcsshim.Statistics{
+			"sandbox1": {
+				Timestamp: now,
+				Network: []hcsshim.NetworkStats{
+					{EndpointId: "ep-shared", BytesReceived: 100, BytesSent: 200},
+				},
+			},
+			"app1": {
+				Timestamp: now,
+				Network: []hcsshim.NetworkStats{
+					{EndpointId: "ep-shared", BytesReceived: 100, BytesSent: 200},
+				},
+			},
+		},
+		endpoints: map[string]*hcsshim.HNSEndpoint{
+			"ep-shared": {
+				Id:               "ep-shared",
+				Name:             "vEthernet",
+				SharedContainers: []string{"sandbox1", "app1"},
+			},
+		},
+	}
+
+	p := &criStatsProvider{hcsshimInterface: shim}
+	stats, err := p.listPodSandboxNetworkStats([]string{"sandbox1"})
+	require.NoError(t, err)
+
+	// app1 shares the sandbox's HNS endpoint, so its stats are correlated to the sandbox instead
+	// of producing a separate entry, and the shared adapter is only counted once.
+	require.Len(t, stats, 1)
+	require.Contains(t, stats, "sandbox1")
+	require.Len(t, stats["sandbox1"].Interfaces, 1)
+	assert.Equal(t, uint64(100), *stats["sandbox1"].Interfaces[0].RxBytes)
+}
+
+func TestMergeInterfaceStats(t *testing.T) {
+	shim := &fakeHcsShim{
+		endpoints: map[string]*hcsshim.HNSEndpoint{
+			"ep-a": {Id: "ep-a", Name: "vEthernet (a)"},
+			"ep-b": {Id: "ep-b", Name: "vEthernet (b)"},
+		},
+	}
+
+	dest := &statsapi.NetworkStats{
+		Interfaces: []statsapi.InterfaceStats{{Name: "vEthernet (a)"}},
+	}
+
+	mergeInterfaceStats(dest, shim, []hcsshim.NetworkStats{
+		{EndpointId: "ep-a"}, // already present, must not be duplicated
+		{EndpointId: "ep-b"}, // new adapter, must be appended
+	})
+
+	require.Len(t, dest.Interfaces, 2)
+	names := sets.NewString()
+	for _, iStat := range dest.Interfaces {
+		names.Insert(iStat.Name)
+	}
+	assert.True(t, names.HasAll("vEthernet (a)", "vEthernet (b)"))
+}
+
+func TestMergeContainersByID(t *testing.T) {
+	base := []hcsshim.ContainerProperties{{ID: "c1"}}
+	additional := []hcsshim.ContainerProperties{{ID: "c1"}, {ID: "hp1"}}
+
+	merged := mergeContainersByID(base, additional)
+
+	// c1 is present in both lists and must only appear once; hp1 only appears in additional and
+	// must still be included. This is the merge windowshimV2.GetContainers uses to fold HostProcess
+	// containers, which a v1-style query alone would miss, into the result.
+	ids := sets.NewString()
+	for _, c := range merged {
+		ids.Insert(c.ID)
+	}
+	assert.Equal(t, sets.NewString("c1", "hp1"), ids)
+}